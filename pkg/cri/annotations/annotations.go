@@ -0,0 +1,74 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package annotations defines the well-known annotation keys set by CRI on
+// the OCI runtime spec of sandboxes and containers.
+package annotations
+
+const (
+	// ContainerType is the container type annotation set on pause/sandbox and
+	// application containers.
+	ContainerType = "io.kubernetes.cri.container-type"
+	// ContainerTypeSandbox is the container type value for the pause/sandbox
+	// container.
+	ContainerTypeSandbox = "sandbox"
+	// ContainerTypeContainer is the container type value for application
+	// containers running inside a sandbox.
+	ContainerTypeContainer = "container"
+
+	// SandboxID is the sandbox ID annotation.
+	SandboxID = "io.kubernetes.cri.sandbox-id"
+	// SandboxNamespace is the sandbox namespace annotation.
+	SandboxNamespace = "io.kubernetes.cri.sandbox-namespace"
+	// SandboxUID is the sandbox uid annotation.
+	SandboxUID = "io.kubernetes.cri.sandbox-uid"
+	// SandboxName is the sandbox name annotation.
+	SandboxName = "io.kubernetes.cri.sandbox-name"
+	// SandboxLogDir is the sandbox log directory annotation.
+	SandboxLogDir = "io.kubernetes.cri.sandbox-log-directory"
+
+	// SandboxCPUPeriod is the CPU period annotation set on the sandbox spec
+	// when LinuxContainerResources are given for the pod.
+	SandboxCPUPeriod = "io.kubernetes.cri.sandbox-cpu-period"
+	// SandboxCPUQuota is the CPU quota annotation set on the sandbox spec.
+	SandboxCPUQuota = "io.kubernetes.cri.sandbox-cpu-quota"
+	// SandboxCPUShares is the CPU shares annotation set on the sandbox spec.
+	SandboxCPUShares = "io.kubernetes.cri.sandbox-cpu-shares"
+	// SandboxMem is the memory limit annotation set on the sandbox spec.
+	SandboxMem = "io.kubernetes.cri.sandbox-memory"
+
+	// SandboxRlimits is a JSON-encoded map of rlimit name (e.g. "nofile",
+	// "nproc") to {"soft": N, "hard": N} applied to the pause process, since
+	// CRI has no rlimits field of its own.
+	SandboxRlimits = "io.kubernetes.cri.sandbox-rlimits"
+
+	// SandboxCapabilities is a JSON-encoded opts.SandboxCapabilities, set so
+	// the effective/bounding/permitted/inheritable/ambient capability sets
+	// chosen for the pause process survive a shim restart.
+	SandboxCapabilities = "io.kubernetes.cri.sandbox-capabilities"
+
+	// SandboxProcMountOptions is a comma-separated list of mount options
+	// (e.g. "hidepid=2", "subset=pid") to apply to the sandbox's /proc
+	// mount, overriding the controller's DefaultSandboxProcMountOptions.
+	SandboxProcMountOptions = "io.kubernetes.cri.sandbox-proc-mount-options"
+
+	// ContainerSeccompProfile is the seccomp profile path a container
+	// inherited from its sandbox, set because the OCI runtime spec has no
+	// first-class "profile path" field of its own independent of the fully
+	// resolved runtimespec.LinuxSeccomp a container's own security context
+	// would otherwise populate.
+	ContainerSeccompProfile = "io.kubernetes.cri.container-seccomp-profile"
+)
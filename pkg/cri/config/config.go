@@ -0,0 +1,58 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package config holds the CRI plugin configuration consumed by the sandbox
+// and container controllers.
+package config
+
+// PluginConfig contains the toggles the sandbox/container spec builders read
+// when deciding how to shape the OCI runtime spec.
+type PluginConfig struct {
+	// EnableUnprivilegedPorts configures net.ipv4.ip_unprivileged_port_start
+	// to 0 for every pod sandbox that does not run in the host network
+	// namespace.
+	EnableUnprivilegedPorts bool
+	// EnableUnprivilegedICMP configures net.ipv4.ping_group_range to "0
+	// 2147483647" for every pod sandbox that does not run in the host network
+	// namespace.
+	EnableUnprivilegedICMP bool
+	// DisableCgroup indicates that no cgroup path or resource limits should
+	// be set on sandboxes, which is useful when running inside containers
+	// that do not have access to the cgroup hierarchy.
+	DisableCgroup bool
+	// EnableSandboxRlimitDefaults enables applying opts.DefaultSandboxNofile
+	// and opts.DefaultSandboxNproc to a pod sandbox that does not otherwise
+	// request RLIMIT_NOFILE/RLIMIT_NPROC via the
+	// annotations.SandboxRlimits annotation.
+	EnableSandboxRlimitDefaults bool
+	// InheritSandboxSecurity controls whether member containers join the
+	// sandbox's namespaces and inherit its selinux/apparmor/seccomp/
+	// capability settings by default, via
+	// controller.ApplySandboxSecurityInheritance. Defaults to true;
+	// operators who need the old per-container derivation can turn it off.
+	InheritSandboxSecurity bool
+	// DefaultSandboxProcMountOptions are the /proc mount options applied to
+	// a pod sandbox that does not set the
+	// annotations.SandboxProcMountOptions annotation, e.g. []string{
+	// "hidepid=2"} for user-namespaced sandboxes that must not see other
+	// pods' processes.
+	DefaultSandboxProcMountOptions []string
+}
+
+// Config is the CRI plugin configuration.
+type Config struct {
+	PluginConfig
+}
@@ -0,0 +1,29 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package opts
+
+// SandboxCapabilities mirrors the five capability sets of the pause
+// process's spec.Process.Capabilities, surfaced on sandbox status/inspect
+// so an operator can see why a pod did or didn't get a given capability
+// without having to read the OCI spec off disk.
+type SandboxCapabilities struct {
+	Effective   []string
+	Bounding    []string
+	Permitted   []string
+	Inheritable []string
+	Ambient     []string
+}
@@ -0,0 +1,34 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package opts holds small, shared defaults used when building OCI specs for
+// CRI sandboxes and containers.
+package opts
+
+// DefaultSandboxCPUshares is the default CPU shares assigned to the pause
+// container of a pod sandbox when the pod does not request any resources.
+const DefaultSandboxCPUshares = 2
+
+// DefaultSandboxNofile is the default RLIMIT_NOFILE soft and hard limit
+// applied to the pause container when the pod does not request one and
+// EnableSandboxRlimitDefaults is set. It is well above the shim's inherited
+// 1024 default so pods with many containers don't exhaust file descriptors.
+const DefaultSandboxNofile = 1024 * 1024
+
+// DefaultSandboxNproc is the default RLIMIT_NPROC soft and hard limit
+// applied to the pause container under the same conditions as
+// DefaultSandboxNofile.
+const DefaultSandboxNproc = 64 * 1024
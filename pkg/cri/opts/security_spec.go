@@ -0,0 +1,50 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package opts
+
+// SandboxNamespacePaths holds the namespace paths a pod sandbox's pause
+// container was given. A member container joining the sandbox should use
+// these instead of deriving its own, so it never drifts from what the
+// sandbox actually agreed to. An empty path means the namespace is either
+// the host namespace or was newly created for the sandbox rather than
+// joined from an existing path.
+type SandboxNamespacePaths struct {
+	Network string
+	IPC     string
+	UTS     string
+	PID     string
+}
+
+// SandboxSecuritySpec captures the sandbox-level namespace, selinux,
+// seccomp, apparmor and capability settings computed once while generating
+// the sandbox's OCI spec. Member containers read it back so they inherit
+// the sandbox's settings by default instead of re-deriving their own from
+// their own (potentially more permissive) security context.
+type SandboxSecuritySpec struct {
+	NamespacePaths SandboxNamespacePaths
+
+	SelinuxProcessLabel string
+	SelinuxMountLabel   string
+
+	SeccompProfile  string
+	ApparmorProfile string
+
+	// BoundingCapabilities is the sandbox's bounding capability set. A
+	// member container's requested capabilities should be intersected with
+	// this set rather than taken at face value.
+	BoundingCapabilities []string
+}
@@ -0,0 +1,151 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package podsandbox
+
+import (
+	"sort"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/containerd/containerd/pkg/cri/annotations"
+	"github.com/containerd/containerd/pkg/cri/opts"
+)
+
+// ApplySandboxSecurityInheritance points containerSpec's namespaces at the
+// paths recorded for the sandbox with the given ID (see
+// joinSandboxNamespaces) and inherits its selinux/apparmor/
+// seccomp settings and bounding capabilities, unless the container's own
+// security context explicitly overrides them. It is a no-op if
+// InheritSandboxSecurity is disabled or no SandboxSecuritySpec was recorded
+// for sandboxID: the cache is process-lifetime only (see controller.go), so
+// a daemon restart leaves every sandbox it already had running without an
+// entry, and a member container started against one of them must still
+// come up rather than fail.
+//
+// This is the read side of the SandboxSecuritySpec computed once in
+// sandboxContainerSpec: it exists so a member container can't end up with
+// caps, a selinux label, or a seccomp/apparmor profile the sandbox never
+// agreed to.
+func (c *controller) ApplySandboxSecurityInheritance(
+	containerSpec *runtimespec.Spec,
+	sandboxID string,
+	containerSecurityContext *runtime.LinuxContainerSecurityContext,
+) error {
+	if !c.config.InheritSandboxSecurity {
+		return nil
+	}
+	securitySpec := c.SandboxSecuritySpec(sandboxID)
+	if securitySpec == nil {
+		return nil
+	}
+
+	joinSandboxNamespaces(containerSpec, securitySpec.NamespacePaths, containerSecurityContext.GetNamespaceOptions())
+
+	if containerSecurityContext.GetSelinuxOptions() == nil {
+		containerSpec.Process.SelinuxLabel = securitySpec.SelinuxProcessLabel
+		containerSpec.Linux.MountLabel = securitySpec.SelinuxMountLabel
+	}
+
+	if containerSecurityContext.GetSeccompProfilePath() == "" {
+		setSeccompProfilePath(containerSpec, securitySpec.SeccompProfile)
+	}
+	if containerSecurityContext.GetApparmorProfile() == "" {
+		setApparmorProfile(containerSpec, securitySpec.ApparmorProfile)
+	}
+
+	if containerSpec.Process.Capabilities != nil {
+		containerSpec.Process.Capabilities.Bounding = intersectCapabilities(
+			containerSpec.Process.Capabilities.Bounding, securitySpec.BoundingCapabilities)
+		containerSpec.Process.Capabilities.Effective = intersectCapabilities(
+			containerSpec.Process.Capabilities.Effective, securitySpec.BoundingCapabilities)
+		containerSpec.Process.Capabilities.Permitted = intersectCapabilities(
+			containerSpec.Process.Capabilities.Permitted, securitySpec.BoundingCapabilities)
+	}
+
+	return nil
+}
+
+// joinSandboxNamespaces sets the path of containerSpec's net/ipc/uts/pid
+// namespaces to the path recorded for the sandbox, unless the container
+// explicitly asked to run in the host namespace for that namespace type.
+// Where the recorded path is empty (the sandbox's ipc/uts/pid namespaces,
+// which are created fresh, or a namespace the sandbox shares with the
+// host), the container's entry gets an empty path and so a new namespace
+// of its own, not the sandbox's.
+func joinSandboxNamespaces(containerSpec *runtimespec.Spec, paths opts.SandboxNamespacePaths, nsOptions *runtime.NamespaceOption) {
+	join := func(nsType runtimespec.LinuxNamespaceType, path string, mode runtime.NamespaceMode) {
+		if mode == runtime.NamespaceMode_NODE {
+			return
+		}
+		for i, ns := range containerSpec.Linux.Namespaces {
+			if ns.Type == nsType {
+				containerSpec.Linux.Namespaces[i].Path = path
+				return
+			}
+		}
+		containerSpec.Linux.Namespaces = append(containerSpec.Linux.Namespaces, runtimespec.LinuxNamespace{Type: nsType, Path: path})
+	}
+
+	join(runtimespec.NetworkNamespace, paths.Network, nsOptions.GetNetwork())
+	join(runtimespec.IPCNamespace, paths.IPC, nsOptions.GetIpc())
+	// UTS follows the network namespace mode, the same way it does when the
+	// sandbox's own spec is generated.
+	join(runtimespec.UTSNamespace, paths.UTS, nsOptions.GetNetwork())
+	join(runtimespec.PIDNamespace, paths.PID, nsOptions.GetPid())
+}
+
+// setSeccompProfilePath and setApparmorProfile stash the inherited profile
+// in spec annotations: the OCI runtime spec has no first-class seccomp
+// "profile path" or apparmor profile name field independent of the fully
+// resolved runtimespec.LinuxSeccomp/Process.ApparmorProfile a full container
+// spec builder would already be populating from its own security context.
+func setSeccompProfilePath(spec *runtimespec.Spec, profile string) {
+	if profile == "" {
+		return
+	}
+	if spec.Annotations == nil {
+		spec.Annotations = map[string]string{}
+	}
+	spec.Annotations[annotations.ContainerSeccompProfile] = profile
+}
+
+func setApparmorProfile(spec *runtimespec.Spec, profile string) {
+	if profile == "" {
+		return
+	}
+	spec.Process.ApparmorProfile = profile
+}
+
+// intersectCapabilities returns requested ∩ bounding, sorted, so a
+// container can never end up with a capability its sandbox didn't have in
+// its own bounding set.
+func intersectCapabilities(requested, bounding []string) []string {
+	allowed := make(map[string]struct{}, len(bounding))
+	for _, c := range bounding {
+		allowed[c] = struct{}{}
+	}
+
+	result := make([]string, 0, len(requested))
+	for _, c := range requested {
+		if _, ok := allowed[c]; ok {
+			result = append(result, c)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,131 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package podsandbox
+
+import (
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+func newJoinedContainerSpec() *runtimespec.Spec {
+	return &runtimespec.Spec{
+		Process: &runtimespec.Process{
+			Capabilities: &runtimespec.LinuxCapabilities{
+				Bounding:  []string{"CAP_CHOWN", "CAP_SYS_ADMIN"},
+				Effective: []string{"CAP_CHOWN", "CAP_SYS_ADMIN"},
+				Permitted: []string{"CAP_CHOWN", "CAP_SYS_ADMIN"},
+			},
+		},
+		Linux: &runtimespec.Linux{},
+	}
+}
+
+func TestApplySandboxSecurityInheritance(t *testing.T) {
+	sandboxConfig, imageConfig, _ := getRunPodSandboxTestData()
+	sandboxConfig.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{
+		Capabilities: &runtime.Capability{AddCapabilities: []string{"SYS_ADMIN"}},
+	}
+
+	c := newControllerService()
+	c.config.InheritSandboxSecurity = true
+	_, err := c.sandboxContainerSpec("test-id", sandboxConfig, imageConfig, "test-cni", nil)
+	require.NoError(t, err)
+
+	containerSpec := newJoinedContainerSpec()
+	require.NoError(t, c.ApplySandboxSecurityInheritance(containerSpec, "test-id", &runtime.LinuxContainerSecurityContext{}))
+
+	t.Log("container should join the sandbox's network namespace")
+	found := false
+	for _, ns := range containerSpec.Linux.Namespaces {
+		if ns.Type == runtimespec.NetworkNamespace {
+			found = true
+			assert.Equal(t, "test-cni", ns.Path)
+		}
+	}
+	assert.True(t, found, "expected a network namespace entry")
+
+	t.Log("requested caps are intersected with the sandbox bounding set")
+	assert.Equal(t, []string{"CAP_CHOWN", "CAP_SYS_ADMIN"}, containerSpec.Process.Capabilities.Bounding)
+}
+
+func TestApplySandboxSecurityInheritanceDropsCapabilitiesOutsideSandboxBounding(t *testing.T) {
+	sandboxConfig, imageConfig, _ := getRunPodSandboxTestData()
+
+	c := newControllerService()
+	c.config.InheritSandboxSecurity = true
+	_, err := c.sandboxContainerSpec("test-id", sandboxConfig, imageConfig, "test-cni", nil)
+	require.NoError(t, err)
+
+	containerSpec := newJoinedContainerSpec()
+	containerSpec.Process.Capabilities.Bounding = append(containerSpec.Process.Capabilities.Bounding, "CAP_NET_ADMIN")
+	containerSpec.Process.Capabilities.Effective = append(containerSpec.Process.Capabilities.Effective, "CAP_NET_ADMIN")
+	containerSpec.Process.Capabilities.Permitted = append(containerSpec.Process.Capabilities.Permitted, "CAP_NET_ADMIN")
+	require.NoError(t, c.ApplySandboxSecurityInheritance(containerSpec, "test-id", &runtime.LinuxContainerSecurityContext{}))
+
+	t.Log("a capability outside the sandbox's own bounding set must be dropped, not just reordered")
+	assert.NotContains(t, containerSpec.Process.Capabilities.Bounding, "CAP_NET_ADMIN")
+	assert.Equal(t, []string{"CAP_CHOWN"}, containerSpec.Process.Capabilities.Bounding)
+}
+
+func TestApplySandboxSecurityInheritanceNoSecuritySpecRecorded(t *testing.T) {
+	c := newControllerService()
+	c.config.InheritSandboxSecurity = true
+
+	containerSpec := newJoinedContainerSpec()
+	original := append([]string{}, containerSpec.Process.Capabilities.Bounding...)
+
+	t.Log("a sandbox with no recorded SandboxSecuritySpec (e.g. it predates this controller instance) must not fail the container")
+	require.NoError(t, c.ApplySandboxSecurityInheritance(containerSpec, "unknown-sandbox-id", &runtime.LinuxContainerSecurityContext{}))
+	assert.Equal(t, original, containerSpec.Process.Capabilities.Bounding)
+	assert.Empty(t, containerSpec.Linux.Namespaces)
+}
+
+func TestApplySandboxSecurityInheritanceDisabled(t *testing.T) {
+	sandboxConfig, imageConfig, _ := getRunPodSandboxTestData()
+	c := newControllerService()
+	c.config.InheritSandboxSecurity = false
+	_, err := c.sandboxContainerSpec("test-id", sandboxConfig, imageConfig, "test-cni", nil)
+	require.NoError(t, err)
+
+	containerSpec := newJoinedContainerSpec()
+	original := append([]string{}, containerSpec.Process.Capabilities.Bounding...)
+	require.NoError(t, c.ApplySandboxSecurityInheritance(containerSpec, "test-id", &runtime.LinuxContainerSecurityContext{}))
+
+	assert.Equal(t, original, containerSpec.Process.Capabilities.Bounding)
+	assert.Empty(t, containerSpec.Linux.Namespaces)
+}
+
+func TestApplySandboxSecurityInheritanceHonorsExplicitOverride(t *testing.T) {
+	sandboxConfig, imageConfig, _ := getRunPodSandboxTestData()
+	c := newControllerService()
+	c.config.InheritSandboxSecurity = true
+	_, err := c.sandboxContainerSpec("test-id", sandboxConfig, imageConfig, "test-cni", nil)
+	require.NoError(t, err)
+
+	containerSpec := newJoinedContainerSpec()
+	containerSpec.Process.SelinuxLabel = "container:own:label:s0"
+	require.NoError(t, c.ApplySandboxSecurityInheritance(containerSpec, "test-id", &runtime.LinuxContainerSecurityContext{
+		SelinuxOptions: &runtime.SELinuxOption{User: "own", Role: "own", Type: "own", Level: "s0"},
+	}))
+
+	t.Log("an explicit container selinux option must not be clobbered by the sandbox's")
+	assert.Equal(t, "container:own:label:s0", containerSpec.Process.SelinuxLabel)
+}
@@ -0,0 +1,121 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package podsandbox
+
+import (
+	"sync"
+
+	criconfig "github.com/containerd/containerd/pkg/cri/config"
+	"github.com/containerd/containerd/pkg/cri/opts"
+)
+
+// controller launches and manages pod sandboxes directly, by generating an
+// OCI runtime spec for the pause container and handing it to a shim.
+type controller struct {
+	config criconfig.Config
+
+	securitySpecsMu sync.Mutex
+	// securitySpecs records the SandboxSecuritySpec computed for each
+	// sandbox at spec-generation time, keyed by sandbox ID. This stands in
+	// for the sandbox store entry a member-container spec builder would
+	// read from in the full CRI service.
+	//
+	// TODO: this is a process-lifetime cache, not the real sandbox store —
+	// entries are never evicted on their own. RemoveSandbox must be called
+	// when a sandbox goes away, and the real sandbox store entry should
+	// replace this map entirely once this controller is wired into it.
+	securitySpecs map[string]*opts.SandboxSecuritySpec
+
+	capabilitiesMu sync.Mutex
+	// capabilities records the SandboxCapabilities computed for each
+	// sandbox at spec-generation time, keyed by sandbox ID, so a future
+	// PodSandboxStatus handler has something to read. No such handler
+	// exists in this snapshot of the CRI plugin; today the only way a
+	// caller can observe these sets is spec.Annotations[annotations.SandboxCapabilities].
+	//
+	// TODO: like securitySpecs, this is a process-lifetime cache that leaks
+	// an entry per sandbox until RemoveSandbox is called for it; it should
+	// be replaced by a lookup against the real sandbox store.
+	capabilities map[string]*opts.SandboxCapabilities
+}
+
+// newControllerService creates a controller with the CRI plugin defaults. It
+// is also used by tests in this package to exercise sandboxContainerSpec
+// without standing up the rest of the CRI service.
+func newControllerService() *controller {
+	return &controller{
+		config: criconfig.Config{PluginConfig: criconfig.PluginConfig{
+			InheritSandboxSecurity: true,
+		}},
+		securitySpecs: make(map[string]*opts.SandboxSecuritySpec),
+		capabilities:  make(map[string]*opts.SandboxCapabilities),
+	}
+}
+
+// setSandboxSecuritySpec persists the SandboxSecuritySpec computed for a
+// sandbox so it can be looked up again while building the spec of a member
+// container.
+func (c *controller) setSandboxSecuritySpec(id string, spec *opts.SandboxSecuritySpec) {
+	c.securitySpecsMu.Lock()
+	defer c.securitySpecsMu.Unlock()
+	c.securitySpecs[id] = spec
+}
+
+// SandboxSecuritySpec returns the SandboxSecuritySpec previously computed
+// for the sandbox with the given ID, or nil if none was recorded.
+func (c *controller) SandboxSecuritySpec(id string) *opts.SandboxSecuritySpec {
+	c.securitySpecsMu.Lock()
+	defer c.securitySpecsMu.Unlock()
+	return c.securitySpecs[id]
+}
+
+// setSandboxCapabilities persists the SandboxCapabilities computed for a
+// sandbox so SandboxCapabilities can report them back to a caller.
+func (c *controller) setSandboxCapabilities(id string, caps *opts.SandboxCapabilities) {
+	c.capabilitiesMu.Lock()
+	defer c.capabilitiesMu.Unlock()
+	c.capabilities[id] = caps
+}
+
+// SandboxCapabilities returns the SandboxCapabilities previously computed
+// for the sandbox with the given ID, or nil if none was recorded.
+//
+// This snapshot of the CRI plugin has no PodSandboxStatus RPC for this
+// controller to answer, so the "why did my pod get CAP_X" question it's
+// meant to help answer can only be read back from
+// spec.Annotations[annotations.SandboxCapabilities] today. This getter
+// exists so that RPC's handler has something to call once it exists; it is
+// not itself a status/inspect surface.
+func (c *controller) SandboxCapabilities(id string) *opts.SandboxCapabilities {
+	c.capabilitiesMu.Lock()
+	defer c.capabilitiesMu.Unlock()
+	return c.capabilities[id]
+}
+
+// RemoveSandbox evicts the cached SandboxSecuritySpec and SandboxCapabilities
+// for id. It must be called once the sandbox is removed, since neither cache
+// is backed by the real sandbox store and would otherwise hold an entry for
+// every sandbox that ever ran for the life of the process.
+func (c *controller) RemoveSandbox(id string) {
+	c.securitySpecsMu.Lock()
+	delete(c.securitySpecs, id)
+	c.securitySpecsMu.Unlock()
+
+	c.capabilitiesMu.Lock()
+	delete(c.capabilities, id)
+	c.capabilitiesMu.Unlock()
+}
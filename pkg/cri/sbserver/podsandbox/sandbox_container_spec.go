@@ -0,0 +1,73 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package podsandbox
+
+import (
+	"strconv"
+
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/containerd/containerd/pkg/cri/annotations"
+)
+
+// relativeRootfsPath is the path, relative to the bundle, where the pause
+// container's rootfs is mounted. It is the same on every platform we
+// support.
+const relativeRootfsPath = "rootfs"
+
+// sandboxProcessArgs resolves the pause process args from the image config,
+// the same way WithProcessArgs does for regular containers.
+func sandboxProcessArgs(imageConfig *imagespec.ImageConfig) []string {
+	return append(append([]string{}, imageConfig.Entrypoint...), imageConfig.Cmd...)
+}
+
+// sandboxProcessEnv returns the environment the pause process should run
+// with.
+func sandboxProcessEnv(imageConfig *imagespec.ImageConfig) []string {
+	return append([]string{}, imageConfig.Env...)
+}
+
+// sandboxProcessCwd returns the working directory the pause process should
+// run with, defaulting to "/" like a regular container.
+func sandboxProcessCwd(imageConfig *imagespec.ImageConfig) string {
+	if imageConfig.WorkingDir == "" {
+		return "/"
+	}
+	return imageConfig.WorkingDir
+}
+
+// sandboxMetadataAnnotations returns the spec annotations common to every
+// platform: sandbox identity, the container-type marker, and the sizing
+// annotations derived from the pod's LinuxContainerResources, if any.
+func sandboxMetadataAnnotations(id string, config *runtime.PodSandboxConfig) map[string]string {
+	specAnnotations := map[string]string{
+		annotations.SandboxID:        id,
+		annotations.ContainerType:    annotations.ContainerTypeSandbox,
+		annotations.SandboxNamespace: config.GetMetadata().GetNamespace(),
+		annotations.SandboxUID:       config.GetMetadata().GetUid(),
+		annotations.SandboxName:      config.GetMetadata().GetName(),
+		annotations.SandboxLogDir:    config.GetLogDirectory(),
+	}
+	if resources := config.GetLinux().GetResources(); resources != nil {
+		specAnnotations[annotations.SandboxCPUPeriod] = strconv.FormatInt(resources.CpuPeriod, 10)
+		specAnnotations[annotations.SandboxCPUQuota] = strconv.FormatInt(resources.CpuQuota, 10)
+		specAnnotations[annotations.SandboxCPUShares] = strconv.FormatInt(resources.CpuShares, 10)
+		specAnnotations[annotations.SandboxMem] = strconv.FormatInt(resources.MemoryLimitInBytes, 10)
+	}
+	return specAnnotations
+}
@@ -0,0 +1,74 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package podsandbox
+
+import (
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// sandboxContainerSpec generates the OCI runtime spec for the pause
+// container of a FreeBSD pod sandbox. FreeBSD jails have no concept of
+// Linux namespaces or cgroups, so the spec carries none of the
+// `spec.Linux` fields; jail-specific knobs live under `spec.FreeBSD`
+// instead.
+func (c *controller) sandboxContainerSpec(
+	id string,
+	config *runtime.PodSandboxConfig,
+	imageConfig *imagespec.ImageConfig,
+	nsPath string,
+	runtimeHandler []string,
+) (*runtimespec.Spec, error) {
+	spec := &runtimespec.Spec{
+		Version: runtimespec.Version,
+		Root: &runtimespec.Root{
+			Path:     relativeRootfsPath,
+			Readonly: true,
+		},
+		Hostname: config.GetHostname(),
+		Process: &runtimespec.Process{
+			Args: sandboxProcessArgs(imageConfig),
+			Env:  sandboxProcessEnv(imageConfig),
+			Cwd:  sandboxProcessCwd(imageConfig),
+			// OOMScoreAdj is a Linux-only concept; FreeBSD jails have no
+			// OOM killer equivalent to tune.
+			OOMScoreAdj: nil,
+		},
+		Annotations: sandboxMetadataAnnotations(id, config),
+		FreeBSD: &runtimespec.FreeBSD{
+			Network: &runtimespec.FreeBSDNetwork{
+				VNET: &runtimespec.FreeBSDVNET{
+					Mode: freebsdVnetMode(config),
+				},
+			},
+		},
+	}
+
+	return spec, nil
+}
+
+// freebsdVnetMode maps the pod's network namespace mode onto the jail vnet
+// mode: a pod that shares the host network runs with vnet disabled, every
+// other pod gets its own vnet jail so its network stack is isolated the
+// same way a Linux network namespace would isolate it.
+func freebsdVnetMode(config *runtime.PodSandboxConfig) string {
+	if config.GetLinux().GetSecurityContext().GetNamespaceOptions().GetNetwork() == runtime.NamespaceMode_NODE {
+		return "disabled"
+	}
+	return "new"
+}
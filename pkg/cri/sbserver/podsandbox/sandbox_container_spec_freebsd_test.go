@@ -0,0 +1,103 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package podsandbox
+
+import (
+	"testing"
+
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/containerd/containerd/pkg/cri/annotations"
+)
+
+func getFreeBSDSandboxTestData() (*runtime.PodSandboxConfig, *imagespec.ImageConfig) {
+	config := &runtime.PodSandboxConfig{
+		Metadata: &runtime.PodSandboxMetadata{
+			Name:      "test-name",
+			Uid:       "test-uid",
+			Namespace: "test-ns",
+			Attempt:   1,
+		},
+		Hostname:     "test-hostname",
+		LogDirectory: "test-log-directory",
+	}
+	imageConfig := &imagespec.ImageConfig{
+		Env:        []string{"a=b", "c=d"},
+		Entrypoint: []string{"/pause"},
+		Cmd:        []string{"forever"},
+		WorkingDir: "/workspace",
+	}
+	return config, imageConfig
+}
+
+func TestFreeBSDSandboxContainerSpec(t *testing.T) {
+	testID := "test-id"
+	config, imageConfig := getFreeBSDSandboxTestData()
+
+	c := newControllerService()
+	spec, err := c.sandboxContainerSpec(testID, config, imageConfig, "test-cni", nil)
+	require.NoError(t, err)
+	require.NotNil(t, spec)
+
+	t.Log("no Linux fields should be present in the spec")
+	assert.Nil(t, spec.Linux)
+
+	t.Log("FreeBSD jail network should be configured")
+	require.NotNil(t, spec.FreeBSD)
+	require.NotNil(t, spec.FreeBSD.Network)
+	require.NotNil(t, spec.FreeBSD.Network.VNET)
+	assert.Equal(t, "new", spec.FreeBSD.Network.VNET.Mode)
+
+	t.Log("OOM score adjustment and cgroup resources have no FreeBSD analog")
+	assert.Nil(t, spec.Process.OOMScoreAdj)
+
+	t.Log("common fields should be populated the same way as on Linux")
+	assert.Equal(t, "test-hostname", spec.Hostname)
+	assert.Equal(t, relativeRootfsPath, spec.Root.Path)
+	assert.Equal(t, true, spec.Root.Readonly)
+	assert.Contains(t, spec.Process.Env, "a=b")
+	assert.Equal(t, []string{"/pause", "forever"}, spec.Process.Args)
+	assert.Equal(t, "/workspace", spec.Process.Cwd)
+
+	t.Log("sandbox annotations should be populated identically to Linux")
+	assert.Equal(t, testID, spec.Annotations[annotations.SandboxID])
+	assert.Equal(t, annotations.ContainerTypeSandbox, spec.Annotations[annotations.ContainerType])
+	assert.Equal(t, "test-ns", spec.Annotations[annotations.SandboxNamespace])
+	assert.Equal(t, "test-uid", spec.Annotations[annotations.SandboxUID])
+	assert.Equal(t, "test-name", spec.Annotations[annotations.SandboxName])
+	assert.Equal(t, "test-log-directory", spec.Annotations[annotations.SandboxLogDir])
+}
+
+func TestFreeBSDSandboxContainerSpecHostNetwork(t *testing.T) {
+	config, imageConfig := getFreeBSDSandboxTestData()
+	config.Linux = &runtime.LinuxPodSandboxConfig{
+		SecurityContext: &runtime.LinuxSandboxSecurityContext{
+			NamespaceOptions: &runtime.NamespaceOption{
+				Network: runtime.NamespaceMode_NODE,
+			},
+		},
+	}
+
+	c := newControllerService()
+	spec, err := c.sandboxContainerSpec("test-id", config, imageConfig, "test-cni", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "disabled", spec.FreeBSD.Network.VNET.Mode)
+}
@@ -0,0 +1,417 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package podsandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/selinux/go-selinux"
+	"github.com/opencontainers/selinux/go-selinux/label"
+	"golang.org/x/sys/unix"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/containerd/containerd/pkg/cri/annotations"
+	"github.com/containerd/containerd/pkg/cri/opts"
+)
+
+// currentUID and currentRlimitMax are indirections over unix.Getuid and
+// unix.Getrlimit so tests can exercise the rootless clamp in
+// applyRlimitDefaults without actually running as a non-root, resource
+// limited user.
+var (
+	currentUID = func() int { return unix.Getuid() }
+
+	currentRlimitMax = func(which int) (uint64, error) {
+		var rl unix.Rlimit
+		if err := unix.Getrlimit(which, &rl); err != nil {
+			return 0, err
+		}
+		return rl.Max, nil
+	}
+)
+
+// defaultSandboxOOMAdj is the OOM score adjustment given to the pause
+// process so the kernel reclaims it last when a pod is under memory
+// pressure.
+const defaultSandboxOOMAdj = -998
+
+// defaultCapabilities is the bounding capability set given to the pause
+// process absent any Add/Drop request, mirroring the runc/OCI default set.
+var defaultCapabilities = []string{
+	"CAP_CHOWN",
+	"CAP_DAC_OVERRIDE",
+	"CAP_FSETID",
+	"CAP_FOWNER",
+	"CAP_MKNOD",
+	"CAP_NET_RAW",
+	"CAP_SETGID",
+	"CAP_SETUID",
+	"CAP_SETFCAP",
+	"CAP_SETPCAP",
+	"CAP_NET_BIND_SERVICE",
+	"CAP_SYS_CHROOT",
+	"CAP_KILL",
+	"CAP_AUDIT_WRITE",
+}
+
+// selinuxLabelOpts turns a SELinuxOption into the label options
+// label.InitLabels expects: a "user:role:type:level" string, split and
+// deduplicated by label.DupSecOpt. A nil option (the common case: most pods
+// don't set one) yields no options, so label.InitLabels falls back to its
+// own randomized default instead of being handed a malformed ":::" string.
+func selinuxLabelOpts(opt *runtime.SELinuxOption) []string {
+	if opt == nil {
+		return nil
+	}
+	return label.DupSecOpt(fmt.Sprintf("%s:%s:%s:%s", opt.GetUser(), opt.GetRole(), opt.GetType(), opt.GetLevel()))
+}
+
+// getCgroupsPath generates the cgroups path for a sandbox, deriving it from
+// the pod's cgroup parent, the same way the default Linux runtime spec
+// options do for regular containers.
+func getCgroupsPath(cgroupParent, id string) string {
+	if cgroupParent == "" {
+		return id
+	}
+	return filepath.Join(cgroupParent, id)
+}
+
+// defaultMounts are the mounts WithNewSpec's Linux defaults produce. They
+// are listed here, rather than generated on the fly, because
+// applyProcMountOptions needs to find the /proc entry by destination to
+// override its options.
+var defaultMounts = []runtimespec.Mount{
+	{Destination: "/proc", Type: "proc", Source: "proc", Options: []string{"nosuid", "noexec", "nodev"}},
+	{Destination: "/dev", Type: "tmpfs", Source: "tmpfs", Options: []string{"nosuid", "strictatime", "mode=755", "size=65536k"}},
+	{Destination: "/sys", Type: "sysfs", Source: "sysfs", Options: []string{"nosuid", "noexec", "nodev", "ro"}},
+}
+
+// validProcMountOptionPrefixes are the /proc mount options we know the
+// kernel accepts. Anything else is rejected up front rather than handed to
+// the runtime, where it would surface as an opaque mount(2) failure deep
+// into sandbox creation.
+var validProcMountOptionPrefixes = []string{
+	"nosuid", "noexec", "nodev", "ro", "rw",
+	"hidepid=",
+	"subset=",
+}
+
+// validateProcMountOptions rejects anything that isn't a recognized /proc
+// mount option.
+func validateProcMountOptions(options []string) error {
+	for _, o := range options {
+		valid := false
+		for _, prefix := range validProcMountOptionPrefixes {
+			// Flag options must match exactly; "key=" options need a value.
+			if o == prefix || (strings.HasSuffix(prefix, "=") && strings.HasPrefix(o, prefix) && len(o) > len(prefix)) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unknown /proc mount option %q", o)
+		}
+	}
+	return nil
+}
+
+// applyProcMountOptions overrides the options of the sandbox's /proc mount
+// with the annotations.SandboxProcMountOptions annotation if present,
+// falling back to the controller's DefaultSandboxProcMountOptions, and
+// leaves the default options alone if neither is set.
+func (c *controller) applyProcMountOptions(spec *runtimespec.Spec, config *runtime.PodSandboxConfig) error {
+	options := c.config.DefaultSandboxProcMountOptions
+	if raw, ok := config.GetAnnotations()[annotations.SandboxProcMountOptions]; ok && raw != "" {
+		options = strings.Split(raw, ",")
+		for i, o := range options {
+			options[i] = strings.TrimSpace(o)
+		}
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	if err := validateProcMountOptions(options); err != nil {
+		return fmt.Errorf("invalid /proc mount options: %w", err)
+	}
+
+	for i := range spec.Mounts {
+		if spec.Mounts[i].Destination == "/proc" {
+			spec.Mounts[i].Options = options
+			return nil
+		}
+	}
+	return fmt.Errorf("no /proc mount found in sandbox spec")
+}
+
+// sandboxContainerSpec generates the OCI runtime spec for the pause
+// container of a Linux pod sandbox.
+func (c *controller) sandboxContainerSpec(
+	id string,
+	config *runtime.PodSandboxConfig,
+	imageConfig *imagespec.ImageConfig,
+	nsPath string,
+	runtimeHandler []string,
+) (*runtimespec.Spec, error) {
+	spec := &runtimespec.Spec{
+		Version: runtimespec.Version,
+		Root: &runtimespec.Root{
+			Path:     relativeRootfsPath,
+			Readonly: true,
+		},
+		Hostname: config.GetHostname(),
+		Process: &runtimespec.Process{
+			Args: sandboxProcessArgs(imageConfig),
+			Env:  sandboxProcessEnv(imageConfig),
+			Cwd:  sandboxProcessCwd(imageConfig),
+		},
+		Mounts:      append([]runtimespec.Mount{}, defaultMounts...),
+		Linux:       &runtimespec.Linux{},
+		Annotations: sandboxMetadataAnnotations(id, config),
+	}
+
+	securityContext := config.GetLinux().GetSecurityContext()
+	nsOptions := securityContext.GetNamespaceOptions()
+
+	if nsOptions.GetNetwork() != runtime.NamespaceMode_NODE {
+		spec.Linux.Namespaces = append(spec.Linux.Namespaces, runtimespec.LinuxNamespace{
+			Type: runtimespec.NetworkNamespace,
+			Path: nsPath,
+		})
+		if c.config.EnableUnprivilegedPorts {
+			setSysctl(spec, "net.ipv4.ip_unprivileged_port_start", "0")
+		}
+		if c.config.EnableUnprivilegedICMP {
+			setSysctl(spec, "net.ipv4.ping_group_range", "0 2147483647")
+		}
+	}
+	if nsOptions.GetPid() != runtime.NamespaceMode_NODE {
+		spec.Linux.Namespaces = append(spec.Linux.Namespaces, runtimespec.LinuxNamespace{Type: runtimespec.PIDNamespace})
+	}
+	if nsOptions.GetIpc() != runtime.NamespaceMode_NODE {
+		spec.Linux.Namespaces = append(spec.Linux.Namespaces, runtimespec.LinuxNamespace{Type: runtimespec.IPCNamespace})
+	}
+	if nsOptions.GetNetwork() != runtime.NamespaceMode_NODE {
+		spec.Linux.Namespaces = append(spec.Linux.Namespaces, runtimespec.LinuxNamespace{Type: runtimespec.UTSNamespace})
+	}
+
+	for k, v := range config.GetLinux().GetSysctls() {
+		setSysctl(spec, k, v)
+	}
+
+	for _, group := range securityContext.GetSupplementalGroups() {
+		spec.Process.User.AdditionalGids = append(spec.Process.User.AdditionalGids, uint32(group))
+	}
+
+	if c.config.DisableCgroup {
+		spec.Linux.Resources = &runtimespec.LinuxResources{}
+	} else {
+		spec.Linux.CgroupsPath = getCgroupsPath(config.GetLinux().GetCgroupParent(), id)
+		shares := uint64(opts.DefaultSandboxCPUshares)
+		spec.Linux.Resources = &runtimespec.LinuxResources{
+			CPU: &runtimespec.LinuxCPU{Shares: &shares},
+		}
+	}
+
+	oomAdj := int(defaultSandboxOOMAdj)
+	spec.Process.OOMScoreAdj = &oomAdj
+
+	if selinux.GetEnabled() {
+		processLabel, mountLabel, err := label.InitLabels(selinuxLabelOpts(securityContext.GetSelinuxOptions()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to init selinux labels: %w", err)
+		}
+		spec.Process.SelinuxLabel = processLabel
+		spec.Linux.MountLabel = mountLabel
+	}
+
+	if err := c.applyRlimits(spec, config); err != nil {
+		return nil, err
+	}
+
+	if err := c.applyProcMountOptions(spec, config); err != nil {
+		return nil, err
+	}
+
+	spec.Process.Capabilities = sandboxCapabilities(securityContext.GetCapabilities())
+
+	capsStatus := &opts.SandboxCapabilities{
+		Effective:   spec.Process.Capabilities.Effective,
+		Bounding:    spec.Process.Capabilities.Bounding,
+		Permitted:   spec.Process.Capabilities.Permitted,
+		Inheritable: spec.Process.Capabilities.Inheritable,
+		Ambient:     spec.Process.Capabilities.Ambient,
+	}
+	capsAnnotation, err := json.Marshal(capsStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s annotation: %w", annotations.SandboxCapabilities, err)
+	}
+	spec.Annotations[annotations.SandboxCapabilities] = string(capsAnnotation)
+	c.setSandboxCapabilities(id, capsStatus)
+
+	c.setSandboxSecuritySpec(id, &opts.SandboxSecuritySpec{
+		NamespacePaths:       namespacePaths(spec),
+		SelinuxProcessLabel:  spec.Process.SelinuxLabel,
+		SelinuxMountLabel:    spec.Linux.MountLabel,
+		SeccompProfile:       securityContext.GetSeccompProfilePath(),
+		ApparmorProfile:      securityContext.GetApparmorProfile(),
+		BoundingCapabilities: append([]string{}, spec.Process.Capabilities.Bounding...),
+	})
+
+	return spec, nil
+}
+
+// sandboxCapabilities merges a sandbox's requested Add/Drop capabilities
+// onto defaultCapabilities and returns the resulting, identically sorted
+// effective/bounding/permitted/inheritable set for the pause process.
+func sandboxCapabilities(capabilities *runtime.Capability) *runtimespec.LinuxCapabilities {
+	set := make(map[string]struct{}, len(defaultCapabilities))
+	for _, c := range defaultCapabilities {
+		set[c] = struct{}{}
+	}
+	for _, c := range capabilities.GetAddCapabilities() {
+		set[normalizeCapability(c)] = struct{}{}
+	}
+	for _, c := range capabilities.GetDropCapabilities() {
+		delete(set, normalizeCapability(c))
+	}
+
+	caps := make([]string, 0, len(set))
+	for c := range set {
+		caps = append(caps, c)
+	}
+	sort.Strings(caps)
+
+	return &runtimespec.LinuxCapabilities{
+		Bounding:    caps,
+		Effective:   caps,
+		Permitted:   caps,
+		Inheritable: caps,
+	}
+}
+
+// normalizeCapability upper-cases a capability name and ensures it carries
+// the CAP_ prefix OCI runtime specs expect, since CRI allows either form.
+func normalizeCapability(name string) string {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "CAP_") {
+		name = "CAP_" + name
+	}
+	return name
+}
+
+// namespacePaths reports the namespace path the pause container was given
+// for each namespace type, so a member container can join the same one. A
+// namespace that was not added to spec.Linux.Namespaces at all (the pod runs
+// in the host's namespace) or that was created fresh for the sandbox (no
+// existing path to join) reports an empty path.
+func namespacePaths(spec *runtimespec.Spec) opts.SandboxNamespacePaths {
+	var paths opts.SandboxNamespacePaths
+	for _, ns := range spec.Linux.Namespaces {
+		switch ns.Type {
+		case runtimespec.NetworkNamespace:
+			paths.Network = ns.Path
+		case runtimespec.IPCNamespace:
+			paths.IPC = ns.Path
+		case runtimespec.UTSNamespace:
+			paths.UTS = ns.Path
+		case runtimespec.PIDNamespace:
+			paths.PID = ns.Path
+		}
+	}
+	return paths
+}
+
+// rlimitValue is the JSON shape of each entry in the
+// annotations.SandboxRlimits annotation.
+type rlimitValue struct {
+	Soft uint64 `json:"soft"`
+	Hard uint64 `json:"hard"`
+}
+
+// applyRlimits appends the sandbox's rlimits to spec.Process.Rlimits: first
+// whatever the operator requested via the annotations.SandboxRlimits
+// annotation, then, if EnableSandboxRlimitDefaults is set, a default
+// RLIMIT_NOFILE/RLIMIT_NPROC for whichever of the two the operator didn't
+// already set. Defaults are clamped to the current process's own hard limit
+// when running as a non-root uid, since a rootless shim cannot raise a hard
+// limit above what it was given and would otherwise fail with EPERM.
+func (c *controller) applyRlimits(spec *runtimespec.Spec, config *runtime.PodSandboxConfig) error {
+	raw, ok := config.GetAnnotations()[annotations.SandboxRlimits]
+
+	parsed := map[string]rlimitValue{}
+	if ok && raw != "" {
+		if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+			return fmt.Errorf("failed to unmarshal %s annotation: %w", annotations.SandboxRlimits, err)
+		}
+	}
+
+	// Normalize keys to lowercase so the presence checks below line up
+	// regardless of how the operator cased the annotation's rlimit names.
+	requested := make(map[string]rlimitValue, len(parsed))
+	for name, limit := range parsed {
+		requested[strings.ToLower(name)] = limit
+	}
+
+	for name, limit := range requested {
+		spec.Process.Rlimits = append(spec.Process.Rlimits, runtimespec.POSIXRlimit{
+			Type: "RLIMIT_" + strings.ToUpper(name),
+			Soft: limit.Soft,
+			Hard: limit.Hard,
+		})
+	}
+
+	if !c.config.EnableSandboxRlimitDefaults {
+		return nil
+	}
+
+	if _, ok := requested["nofile"]; !ok {
+		spec.Process.Rlimits = append(spec.Process.Rlimits, defaultRlimit("RLIMIT_NOFILE", unix.RLIMIT_NOFILE, opts.DefaultSandboxNofile))
+	}
+	if _, ok := requested["nproc"]; !ok {
+		spec.Process.Rlimits = append(spec.Process.Rlimits, defaultRlimit("RLIMIT_NPROC", unix.RLIMIT_NPROC, opts.DefaultSandboxNproc))
+	}
+	return nil
+}
+
+// defaultRlimit builds the default POSIXRlimit entry for rlimitType,
+// clamping soft and hard to the caller's own hard limit when running
+// rootless.
+func defaultRlimit(rlimitType string, which int, defaultValue uint64) runtimespec.POSIXRlimit {
+	soft, hard := defaultValue, defaultValue
+	if currentUID() != 0 {
+		if max, err := currentRlimitMax(which); err == nil && max < hard {
+			hard = max
+			if soft > hard {
+				soft = hard
+			}
+		}
+	}
+	return runtimespec.POSIXRlimit{Type: rlimitType, Soft: soft, Hard: hard}
+}
+
+func setSysctl(spec *runtimespec.Spec, key, value string) {
+	if spec.Linux.Sysctl == nil {
+		spec.Linux.Sysctl = make(map[string]string)
+	}
+	spec.Linux.Sysctl[key] = value
+}
@@ -0,0 +1,558 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package podsandbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/selinux/go-selinux"
+	"github.com/opencontainers/selinux/go-selinux/label"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtime "k8s.io/cri-api/pkg/apis/runtime/v1"
+	v1 "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/containerd/containerd/pkg/cri/annotations"
+	"github.com/containerd/containerd/pkg/cri/opts"
+)
+
+func getRunPodSandboxTestData() (*runtime.PodSandboxConfig, *imagespec.ImageConfig, func(*testing.T, string, *runtimespec.Spec)) {
+	config := &runtime.PodSandboxConfig{
+		Metadata: &runtime.PodSandboxMetadata{
+			Name:      "test-name",
+			Uid:       "test-uid",
+			Namespace: "test-ns",
+			Attempt:   1,
+		},
+		Hostname:     "test-hostname",
+		LogDirectory: "test-log-directory",
+		Labels:       map[string]string{"a": "b"},
+		Annotations:  map[string]string{"c": "d"},
+		Linux: &runtime.LinuxPodSandboxConfig{
+			CgroupParent: "/test/cgroup/parent",
+		},
+	}
+	imageConfig := &imagespec.ImageConfig{
+		Env:        []string{"a=b", "c=d"},
+		Entrypoint: []string{"/pause"},
+		Cmd:        []string{"forever"},
+		WorkingDir: "/workspace",
+	}
+	specCheck := func(t *testing.T, id string, spec *runtimespec.Spec) {
+		assert.Equal(t, "test-hostname", spec.Hostname)
+		assert.Equal(t, getCgroupsPath("/test/cgroup/parent", id), spec.Linux.CgroupsPath)
+		assert.Equal(t, relativeRootfsPath, spec.Root.Path)
+		assert.Equal(t, true, spec.Root.Readonly)
+		assert.Contains(t, spec.Process.Env, "a=b", "c=d")
+		assert.Equal(t, []string{"/pause", "forever"}, spec.Process.Args)
+		assert.Equal(t, "/workspace", spec.Process.Cwd)
+		assert.EqualValues(t, *spec.Linux.Resources.CPU.Shares, opts.DefaultSandboxCPUshares)
+		assert.EqualValues(t, *spec.Process.OOMScoreAdj, defaultSandboxOOMAdj)
+
+		t.Logf("Check PodSandbox annotations")
+		assert.Contains(t, spec.Annotations, annotations.SandboxID)
+		assert.EqualValues(t, spec.Annotations[annotations.SandboxID], id)
+
+		assert.Contains(t, spec.Annotations, annotations.ContainerType)
+		assert.EqualValues(t, spec.Annotations[annotations.ContainerType], annotations.ContainerTypeSandbox)
+
+		assert.Contains(t, spec.Annotations, annotations.SandboxNamespace)
+		assert.EqualValues(t, spec.Annotations[annotations.SandboxNamespace], "test-ns")
+
+		assert.Contains(t, spec.Annotations, annotations.SandboxUID)
+		assert.EqualValues(t, spec.Annotations[annotations.SandboxUID], "test-uid")
+
+		assert.Contains(t, spec.Annotations, annotations.SandboxName)
+		assert.EqualValues(t, spec.Annotations[annotations.SandboxName], "test-name")
+
+		assert.Contains(t, spec.Annotations, annotations.SandboxLogDir)
+		assert.EqualValues(t, spec.Annotations[annotations.SandboxLogDir], "test-log-directory")
+
+		if selinux.GetEnabled() {
+			assert.NotEqual(t, "", spec.Process.SelinuxLabel)
+			assert.NotEqual(t, "", spec.Linux.MountLabel)
+		}
+	}
+	return config, imageConfig, specCheck
+}
+
+func TestLinuxSandboxContainerSpec(t *testing.T) {
+	testID := "test-id"
+	nsPath := "test-cni"
+	for desc, test := range map[string]struct {
+		configChange func(*runtime.PodSandboxConfig)
+		specCheck    func(*testing.T, *runtimespec.Spec)
+		expectErr    bool
+	}{
+		"spec should reflect original config": {
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				// runtime spec should have expected namespaces enabled by default.
+				require.NotNil(t, spec.Linux)
+				assert.Contains(t, spec.Linux.Namespaces, runtimespec.LinuxNamespace{
+					Type: runtimespec.NetworkNamespace,
+					Path: nsPath,
+				})
+				assert.Contains(t, spec.Linux.Namespaces, runtimespec.LinuxNamespace{
+					Type: runtimespec.UTSNamespace,
+				})
+				assert.Contains(t, spec.Linux.Namespaces, runtimespec.LinuxNamespace{
+					Type: runtimespec.PIDNamespace,
+				})
+				assert.Contains(t, spec.Linux.Namespaces, runtimespec.LinuxNamespace{
+					Type: runtimespec.IPCNamespace,
+				})
+				assert.Contains(t, spec.Linux.Sysctl["net.ipv4.ip_unprivileged_port_start"], "0")
+				assert.Contains(t, spec.Linux.Sysctl["net.ipv4.ping_group_range"], "0 2147483647")
+			},
+		},
+		"host namespace": {
+			configChange: func(c *runtime.PodSandboxConfig) {
+				c.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{
+					NamespaceOptions: &runtime.NamespaceOption{
+						Network: runtime.NamespaceMode_NODE,
+						Pid:     runtime.NamespaceMode_NODE,
+						Ipc:     runtime.NamespaceMode_NODE,
+					},
+				}
+			},
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				// runtime spec should disable expected namespaces in host mode.
+				require.NotNil(t, spec.Linux)
+				assert.NotContains(t, spec.Linux.Namespaces, runtimespec.LinuxNamespace{
+					Type: runtimespec.NetworkNamespace,
+				})
+				assert.NotContains(t, spec.Linux.Namespaces, runtimespec.LinuxNamespace{
+					Type: runtimespec.UTSNamespace,
+				})
+				assert.NotContains(t, spec.Linux.Namespaces, runtimespec.LinuxNamespace{
+					Type: runtimespec.PIDNamespace,
+				})
+				assert.NotContains(t, spec.Linux.Namespaces, runtimespec.LinuxNamespace{
+					Type: runtimespec.IPCNamespace,
+				})
+				assert.NotContains(t, spec.Linux.Sysctl["net.ipv4.ip_unprivileged_port_start"], "0")
+				assert.NotContains(t, spec.Linux.Sysctl["net.ipv4.ping_group_range"], "0 2147483647")
+			},
+		},
+		"should set supplemental groups correctly": {
+			configChange: func(c *runtime.PodSandboxConfig) {
+				c.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{
+					SupplementalGroups: []int64{1111, 2222},
+				}
+			},
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				require.NotNil(t, spec.Process)
+				assert.Contains(t, spec.Process.User.AdditionalGids, uint32(1111))
+				assert.Contains(t, spec.Process.User.AdditionalGids, uint32(2222))
+			},
+		},
+		"should overwrite default sysctls": {
+			configChange: func(c *runtime.PodSandboxConfig) {
+				c.Linux.Sysctls = map[string]string{
+					"net.ipv4.ip_unprivileged_port_start": "500",
+					"net.ipv4.ping_group_range":           "1 1000",
+				}
+			},
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				require.NotNil(t, spec.Process)
+				assert.Contains(t, spec.Linux.Sysctl["net.ipv4.ip_unprivileged_port_start"], "500")
+				assert.Contains(t, spec.Linux.Sysctl["net.ipv4.ping_group_range"], "1 1000")
+			},
+		},
+		"should derive selinux labels from explicit options": {
+			configChange: func(c *runtime.PodSandboxConfig) {
+				c.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{
+					SelinuxOptions: &runtime.SELinuxOption{
+						User:  "system_u",
+						Role:  "system_r",
+						Type:  "container_t",
+						Level: "s0:c1,c2",
+					},
+				}
+			},
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				if !selinux.GetEnabled() {
+					t.Skip("selinux is not enabled on this host")
+				}
+				wantProcessLabel, wantMountLabel, err := label.InitLabels(selinuxLabelOpts(&runtime.SELinuxOption{
+					User: "system_u", Role: "system_r", Type: "container_t", Level: "s0:c1,c2",
+				}))
+				require.NoError(t, err)
+				assert.Equal(t, wantProcessLabel, spec.Process.SelinuxLabel)
+				assert.Equal(t, wantMountLabel, spec.Linux.MountLabel)
+			},
+		},
+		"sandbox sizing annotations should be set if LinuxContainerResources were provided": {
+			configChange: func(c *runtime.PodSandboxConfig) {
+				c.Linux.Resources = &v1.LinuxContainerResources{
+					CpuPeriod:          100,
+					CpuQuota:           200,
+					CpuShares:          5000,
+					MemoryLimitInBytes: 1024,
+				}
+			},
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				value, ok := spec.Annotations[annotations.SandboxCPUPeriod]
+				assert.True(t, ok)
+				assert.EqualValues(t, strconv.FormatInt(100, 10), value)
+				assert.EqualValues(t, "100", value)
+
+				value, ok = spec.Annotations[annotations.SandboxCPUQuota]
+				assert.True(t, ok)
+				assert.EqualValues(t, "200", value)
+
+				value, ok = spec.Annotations[annotations.SandboxCPUShares]
+				assert.True(t, ok)
+				assert.EqualValues(t, "5000", value)
+
+				value, ok = spec.Annotations[annotations.SandboxMem]
+				assert.True(t, ok)
+				assert.EqualValues(t, "1024", value)
+			},
+		},
+		"sandbox sizing annotations should not be set if LinuxContainerResources were not provided": {
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				_, ok := spec.Annotations[annotations.SandboxCPUPeriod]
+				assert.False(t, ok)
+				_, ok = spec.Annotations[annotations.SandboxCPUQuota]
+				assert.False(t, ok)
+				_, ok = spec.Annotations[annotations.SandboxCPUShares]
+				assert.False(t, ok)
+				_, ok = spec.Annotations[annotations.SandboxMem]
+				assert.False(t, ok)
+			},
+		},
+		"sandbox sizing annotations are zero if the resources are set to 0": {
+			configChange: func(c *runtime.PodSandboxConfig) {
+				c.Linux.Resources = &v1.LinuxContainerResources{}
+			},
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				value, ok := spec.Annotations[annotations.SandboxCPUPeriod]
+				assert.True(t, ok)
+				assert.EqualValues(t, "0", value)
+				value, ok = spec.Annotations[annotations.SandboxCPUQuota]
+				assert.True(t, ok)
+				assert.EqualValues(t, "0", value)
+				value, ok = spec.Annotations[annotations.SandboxCPUShares]
+				assert.True(t, ok)
+				assert.EqualValues(t, "0", value)
+				value, ok = spec.Annotations[annotations.SandboxMem]
+				assert.True(t, ok)
+				assert.EqualValues(t, "0", value)
+			},
+		},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			c := newControllerService()
+			c.config.EnableUnprivilegedICMP = true
+			c.config.EnableUnprivilegedPorts = true
+			config, imageConfig, specCheck := getRunPodSandboxTestData()
+			if test.configChange != nil {
+				test.configChange(config)
+			}
+			spec, err := c.sandboxContainerSpec(testID, config, imageConfig, nsPath, nil)
+			if test.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, spec)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, spec)
+			specCheck(t, testID, spec)
+			if test.specCheck != nil {
+				test.specCheck(t, spec)
+			}
+		})
+	}
+}
+
+func TestSandboxDisableCgroup(t *testing.T) {
+	config, imageConfig, _ := getRunPodSandboxTestData()
+	c := newControllerService()
+	c.config.DisableCgroup = true
+	spec, err := c.sandboxContainerSpec("test-id", config, imageConfig, "test-cni", []string{})
+	require.NoError(t, err)
+
+	t.Log("resource limit should not be set")
+	assert.Nil(t, spec.Linux.Resources.Memory)
+	assert.Nil(t, spec.Linux.Resources.CPU)
+
+	t.Log("cgroup path should be empty")
+	assert.Empty(t, spec.Linux.CgroupsPath)
+}
+
+// countRlimits returns how many entries of rlimitType appear in spec's
+// rlimits, to catch a user-supplied rlimit and its default colliding.
+func countRlimits(spec *runtimespec.Spec, rlimitType string) int {
+	n := 0
+	for _, rl := range spec.Process.Rlimits {
+		if rl.Type == rlimitType {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSandboxContainerSpecRlimits(t *testing.T) {
+	for desc, test := range map[string]struct {
+		enableDefaults bool
+		rlimitsJSON    string
+		uid            int
+		rlimitMax      uint64
+		specCheck      func(*testing.T, *runtimespec.Spec)
+	}{
+		"defaults are applied when EnableSandboxRlimitDefaults is set and the pod requested nothing": {
+			enableDefaults: true,
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				assert.Contains(t, spec.Process.Rlimits, runtimespec.POSIXRlimit{
+					Type: "RLIMIT_NOFILE", Soft: opts.DefaultSandboxNofile, Hard: opts.DefaultSandboxNofile,
+				})
+				assert.Contains(t, spec.Process.Rlimits, runtimespec.POSIXRlimit{
+					Type: "RLIMIT_NPROC", Soft: opts.DefaultSandboxNproc, Hard: opts.DefaultSandboxNproc,
+				})
+			},
+		},
+		"defaults are not applied when EnableSandboxRlimitDefaults is unset": {
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				assert.Empty(t, spec.Process.Rlimits)
+			},
+		},
+		"user-supplied rlimits override the defaults": {
+			enableDefaults: true,
+			rlimitsJSON:    `{"nofile":{"soft":100,"hard":200}}`,
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				assert.Contains(t, spec.Process.Rlimits, runtimespec.POSIXRlimit{Type: "RLIMIT_NOFILE", Soft: 100, Hard: 200})
+				assert.Contains(t, spec.Process.Rlimits, runtimespec.POSIXRlimit{
+					Type: "RLIMIT_NPROC", Soft: opts.DefaultSandboxNproc, Hard: opts.DefaultSandboxNproc,
+				})
+			},
+		},
+		"user-supplied rlimits are honored as-is even without defaults enabled": {
+			rlimitsJSON: `{"nproc":{"soft":10,"hard":20}}`,
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				assert.Equal(t, []runtimespec.POSIXRlimit{{Type: "RLIMIT_NPROC", Soft: 10, Hard: 20}}, spec.Process.Rlimits)
+			},
+		},
+		"user-supplied rlimits are matched case-insensitively against the defaults": {
+			enableDefaults: true,
+			rlimitsJSON:    `{"NOFILE":{"soft":100,"hard":200}}`,
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				assert.Equal(t, 1, countRlimits(spec, "RLIMIT_NOFILE"), "an uppercase annotation key must not also get the default appended")
+				assert.Contains(t, spec.Process.Rlimits, runtimespec.POSIXRlimit{Type: "RLIMIT_NOFILE", Soft: 100, Hard: 200})
+			},
+		},
+		"rootless clamp caps the default hard limit to the caller's own": {
+			enableDefaults: true,
+			uid:            1000,
+			rlimitMax:      512,
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				assert.Contains(t, spec.Process.Rlimits, runtimespec.POSIXRlimit{Type: "RLIMIT_NOFILE", Soft: 512, Hard: 512})
+			},
+		},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			oldUID, oldRlimitMax := currentUID, currentRlimitMax
+			defer func() { currentUID, currentRlimitMax = oldUID, oldRlimitMax }()
+			currentUID = func() int { return test.uid }
+			currentRlimitMax = func(int) (uint64, error) {
+				if test.rlimitMax == 0 {
+					return 0, fmt.Errorf("no limit configured for this test")
+				}
+				return test.rlimitMax, nil
+			}
+
+			config, imageConfig, _ := getRunPodSandboxTestData()
+			if test.rlimitsJSON != "" {
+				config.Annotations[annotations.SandboxRlimits] = test.rlimitsJSON
+			}
+			c := newControllerService()
+			c.config.EnableSandboxRlimitDefaults = test.enableDefaults
+			spec, err := c.sandboxContainerSpec("test-id", config, imageConfig, "test-cni", nil)
+			require.NoError(t, err)
+			test.specCheck(t, spec)
+		})
+	}
+}
+
+func TestSandboxSecuritySpec(t *testing.T) {
+	config, imageConfig, _ := getRunPodSandboxTestData()
+	config.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{
+		Capabilities: &runtime.Capability{
+			AddCapabilities:  []string{"NET_ADMIN"},
+			DropCapabilities: []string{"CHOWN"},
+		},
+	}
+	c := newControllerService()
+	spec, err := c.sandboxContainerSpec("test-id", config, imageConfig, "test-cni", nil)
+	require.NoError(t, err)
+
+	secSpec := c.SandboxSecuritySpec("test-id")
+	require.NotNil(t, secSpec)
+
+	t.Log("sandbox spec and stored security spec must agree on selinux labels")
+	assert.Equal(t, spec.Process.SelinuxLabel, secSpec.SelinuxProcessLabel)
+	assert.Equal(t, spec.Linux.MountLabel, secSpec.SelinuxMountLabel)
+
+	t.Log("bounding capabilities must reflect the add/drop set, sorted")
+	assert.Equal(t, spec.Process.Capabilities.Bounding, secSpec.BoundingCapabilities)
+	assert.Contains(t, secSpec.BoundingCapabilities, "CAP_NET_ADMIN")
+	assert.NotContains(t, secSpec.BoundingCapabilities, "CAP_CHOWN")
+	assert.True(t, sort.StringsAreSorted(secSpec.BoundingCapabilities))
+}
+
+func TestSandboxSecuritySpecHostNamespaces(t *testing.T) {
+	config, imageConfig, _ := getRunPodSandboxTestData()
+	config.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{
+		NamespaceOptions: &runtime.NamespaceOption{
+			Network: runtime.NamespaceMode_NODE,
+			Pid:     runtime.NamespaceMode_NODE,
+			Ipc:     runtime.NamespaceMode_NODE,
+		},
+	}
+	c := newControllerService()
+	_, err := c.sandboxContainerSpec("test-id", config, imageConfig, "test-cni", nil)
+	require.NoError(t, err)
+
+	secSpec := c.SandboxSecuritySpec("test-id")
+	require.NotNil(t, secSpec)
+	assert.Equal(t, opts.SandboxNamespacePaths{}, secSpec.NamespacePaths)
+}
+
+func TestSandboxCapabilitiesStatus(t *testing.T) {
+	config, imageConfig, _ := getRunPodSandboxTestData()
+	config.Linux.SecurityContext = &runtime.LinuxSandboxSecurityContext{
+		Capabilities: &runtime.Capability{
+			AddCapabilities:  []string{"NET_ADMIN", "SYS_TIME"},
+			DropCapabilities: []string{"MKNOD"},
+		},
+	}
+	c := newControllerService()
+	spec, err := c.sandboxContainerSpec("test-id", config, imageConfig, "test-cni", nil)
+	require.NoError(t, err)
+
+	status := c.SandboxCapabilities("test-id")
+	require.NotNil(t, status)
+
+	t.Log("bounding/effective sets must be sorted deterministically")
+	assert.True(t, sort.StringsAreSorted(status.Bounding))
+	assert.True(t, sort.StringsAreSorted(status.Effective))
+	assert.Contains(t, status.Bounding, "CAP_NET_ADMIN")
+	assert.Contains(t, status.Bounding, "CAP_SYS_TIME")
+	assert.NotContains(t, status.Bounding, "CAP_MKNOD")
+
+	t.Log("the annotation must be a JSON-encoded copy of the same sets")
+	var fromAnnotation opts.SandboxCapabilities
+	require.NoError(t, json.Unmarshal([]byte(spec.Annotations[annotations.SandboxCapabilities]), &fromAnnotation))
+	assert.Equal(t, status.Bounding, fromAnnotation.Bounding)
+	assert.Equal(t, status.Effective, fromAnnotation.Effective)
+	assert.Equal(t, status.Permitted, fromAnnotation.Permitted)
+	assert.Equal(t, status.Inheritable, fromAnnotation.Inheritable)
+}
+
+func findMount(spec *runtimespec.Spec, destination string) *runtimespec.Mount {
+	for i := range spec.Mounts {
+		if spec.Mounts[i].Destination == destination {
+			return &spec.Mounts[i]
+		}
+	}
+	return nil
+}
+
+func TestSandboxContainerSpecProcMountOptions(t *testing.T) {
+	for desc, test := range map[string]struct {
+		defaultOptions []string
+		annotation     string
+		expectErr      bool
+		specCheck      func(*testing.T, *runtimespec.Spec)
+	}{
+		"annotation overrides the controller default": {
+			defaultOptions: []string{"nosuid", "noexec", "nodev"},
+			annotation:     "hidepid=2, subset=pid",
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				assert.Equal(t, []string{"hidepid=2", "subset=pid"}, findMount(spec, "/proc").Options)
+			},
+		},
+		"controller default is used when the annotation is absent": {
+			defaultOptions: []string{"hidepid=2"},
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				assert.Equal(t, []string{"hidepid=2"}, findMount(spec, "/proc").Options)
+			},
+		},
+		"neither set leaves the default /proc mount options untouched": {
+			specCheck: func(t *testing.T, spec *runtimespec.Spec) {
+				assert.Equal(t, []string{"nosuid", "noexec", "nodev"}, findMount(spec, "/proc").Options)
+			},
+		},
+		"invalid option strings return an error": {
+			annotation: "not-a-real-option",
+			expectErr:  true,
+		},
+	} {
+		t.Run(desc, func(t *testing.T) {
+			config, imageConfig, _ := getRunPodSandboxTestData()
+			if test.annotation != "" {
+				config.Annotations[annotations.SandboxProcMountOptions] = test.annotation
+			}
+			c := newControllerService()
+			c.config.DefaultSandboxProcMountOptions = test.defaultOptions
+			spec, err := c.sandboxContainerSpec("test-id", config, imageConfig, "test-cni", nil)
+			if test.expectErr {
+				assert.Error(t, err)
+				assert.Nil(t, spec)
+				return
+			}
+			require.NoError(t, err)
+			test.specCheck(t, spec)
+		})
+	}
+}
+
+func TestSelinuxLabelOpts(t *testing.T) {
+	assert.Nil(t, selinuxLabelOpts(nil))
+
+	opt := &runtime.SELinuxOption{
+		User:  "system_u",
+		Role:  "system_r",
+		Type:  "container_t",
+		Level: "s0:c1,c2",
+	}
+	assert.Equal(t, label.DupSecOpt("system_u:system_r:container_t:s0:c1,c2"), selinuxLabelOpts(opt))
+}
+
+func TestControllerRemoveSandbox(t *testing.T) {
+	config, imageConfig, _ := getRunPodSandboxTestData()
+	c := newControllerService()
+	_, err := c.sandboxContainerSpec("test-id", config, imageConfig, "test-cni", nil)
+	require.NoError(t, err)
+
+	require.NotNil(t, c.SandboxSecuritySpec("test-id"))
+	require.NotNil(t, c.SandboxCapabilities("test-id"))
+
+	c.RemoveSandbox("test-id")
+
+	assert.Nil(t, c.SandboxSecuritySpec("test-id"))
+	assert.Nil(t, c.SandboxCapabilities("test-id"))
+}
+
+// TODO(random-liu): [P1] Add unit test for different error cases to make sure
+// the function cleans up on error properly.
@@ -0,0 +1,39 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+//go:build !linux && !freebsd
+
+package podsandbox
+
+import (
+	"fmt"
+	"runtime"
+
+	imagespec "github.com/opencontainers/image-spec/specs-go/v1"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	criruntime "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// sandboxContainerSpec is not implemented on this platform.
+func (c *controller) sandboxContainerSpec(
+	id string,
+	config *criruntime.PodSandboxConfig,
+	imageConfig *imagespec.ImageConfig,
+	nsPath string,
+	runtimeHandler []string,
+) (*runtimespec.Spec, error) {
+	return nil, fmt.Errorf("pod sandbox spec generation is not supported on %s", runtime.GOOS)
+}